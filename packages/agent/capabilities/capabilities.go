@@ -0,0 +1,111 @@
+// Package capabilities fingerprints the host a buildd-agent runs on so the
+// server can match tasks to workers equipped to run them.
+package capabilities
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Set is a stable, sorted set of capability strings such as "go:1.22",
+// "os:linux/amd64", "executor:claude-oauth", or "label:gpu=a100". It's
+// included in every ClaimTasksRequest.
+type Set []string
+
+// Probe fingerprints the host: OS/arch, CPU count, free memory, and
+// installed toolchains, then merges in executors (already known-available,
+// since detecting that requires the caller's executor registry) and
+// user-supplied labels from repeated --label key=value flags.
+func Probe(executors []string, labels map[string]string) Set {
+	var caps []string
+
+	caps = append(caps, fmt.Sprintf("os:%s/%s", runtime.GOOS, runtime.GOARCH))
+	caps = append(caps, fmt.Sprintf("cpus:%d", runtime.NumCPU()))
+
+	if mb, ok := freeMemMB(); ok {
+		caps = append(caps, fmt.Sprintf("mem:%dmb", mb))
+	}
+
+	for _, tc := range toolchains {
+		if version, ok := tc.detect(); ok {
+			caps = append(caps, fmt.Sprintf("%s:%s", tc.name, version))
+		}
+	}
+
+	for _, name := range executors {
+		caps = append(caps, "executor:"+name)
+	}
+
+	for k, v := range labels {
+		caps = append(caps, fmt.Sprintf("label:%s=%s", k, v))
+	}
+
+	sort.Strings(caps)
+	return caps
+}
+
+// toolchain detects the version of a single CLI by running it and matching
+// version against its output.
+type toolchain struct {
+	name    string
+	command string
+	args    []string
+	version *regexp.Regexp // first capture group is the version string
+}
+
+func (t toolchain) detect() (string, bool) {
+	out, err := exec.Command(t.command, t.args...).CombinedOutput()
+	if err != nil {
+		return "", false
+	}
+	m := t.version.FindSubmatch(out)
+	if m == nil {
+		return "", false
+	}
+	return string(m[1]), true
+}
+
+var toolchains = []toolchain{
+	{"go", "go", []string{"version"}, regexp.MustCompile(`go(\d+\.\d+(?:\.\d+)?)`)},
+	{"node", "node", []string{"--version"}, regexp.MustCompile(`v?(\d+\.\d+\.\d+)`)},
+	{"python3", "python3", []string{"--version"}, regexp.MustCompile(`(\d+\.\d+\.\d+)`)},
+	{"cargo", "cargo", []string{"--version"}, regexp.MustCompile(`cargo (\d+\.\d+\.\d+)`)},
+	{"docker", "docker", []string{"--version"}, regexp.MustCompile(`(\d+\.\d+\.\d+)`)},
+	{"kubectl", "kubectl", []string{"version", "--client"}, regexp.MustCompile(`(\d+\.\d+\.\d+)`)},
+}
+
+// freeMemMB reads available memory in MB from /proc/meminfo. It only works
+// on Linux, which is fine: ok is false anywhere else and the mem: entry is
+// simply omitted.
+func freeMemMB() (int, bool) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "MemAvailable:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0, false
+		}
+		kb, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return 0, false
+		}
+		return kb / 1024, true
+	}
+	return 0, false
+}
@@ -0,0 +1,70 @@
+package capabilities
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// withToolchains swaps the package-level toolchains list for the duration of
+// a test, so Probe's detection doesn't depend on what's actually installed
+// on the machine running the test.
+func withToolchains(t *testing.T, stub []toolchain) {
+	t.Helper()
+	orig := toolchains
+	toolchains = stub
+	t.Cleanup(func() { toolchains = orig })
+}
+
+func TestProbeIncludesExecutorsAndLabels(t *testing.T) {
+	withToolchains(t, []toolchain{
+		{"echo", "echo", []string{"1.2.3"}, regexp.MustCompile(`(\d+\.\d+\.\d+)`)},
+	})
+
+	caps := Probe([]string{"claude-oauth", "aider"}, map[string]string{"gpu": "a100"})
+
+	want := map[string]bool{
+		"echo:1.2.3":            false,
+		"executor:claude-oauth": false,
+		"executor:aider":        false,
+		"label:gpu=a100":        false,
+	}
+	for _, c := range caps {
+		if _, ok := want[c]; ok {
+			want[c] = true
+		}
+	}
+	for c, found := range want {
+		if !found {
+			t.Errorf("Probe() result %v missing expected capability %q", caps, c)
+		}
+	}
+}
+
+func TestProbeOmitsUndetectedToolchain(t *testing.T) {
+	withToolchains(t, []toolchain{
+		{"nonexistent-tool", "buildd-definitely-not-a-real-binary", nil, regexp.MustCompile(`(\d+)`)},
+	})
+
+	caps := Probe(nil, nil)
+
+	for _, c := range caps {
+		if strings.HasPrefix(c, "nonexistent-tool:") {
+			t.Errorf("Probe() unexpectedly included %q", c)
+		}
+	}
+}
+
+func TestProbeIsSorted(t *testing.T) {
+	withToolchains(t, nil)
+
+	caps := Probe([]string{"b-executor", "a-executor"}, map[string]string{"z": "1", "a": "2"})
+
+	sorted := make(Set, len(caps))
+	copy(sorted, caps)
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i-1] > sorted[i] {
+			t.Fatalf("Probe() result %v is not sorted", caps)
+		}
+	}
+}
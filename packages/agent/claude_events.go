@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// maxClaudeLineSize bounds the scanner's per-line buffer so a runaway tool
+// output (e.g. a huge test log) can't OOM the agent process.
+const maxClaudeLineSize = 4 * 1024 * 1024
+
+// claudeStreamEvent is one line of `claude --output-format stream-json`
+// output. The CLI emits envelope events (system/assistant/user/result), not
+// raw Messages API SSE events, so Message carries the nested Anthropic
+// message for the "assistant"/"user" cases and Usage/Result are only
+// populated on the final "result" event.
+type claudeStreamEvent struct {
+	Type    string         `json:"type"`
+	Subtype string         `json:"subtype,omitempty"`
+	Message *claudeMessage `json:"message,omitempty"`
+	Result  string         `json:"result,omitempty"`
+	Usage   *claudeUsage   `json:"usage,omitempty"`
+}
+
+// claudeMessage mirrors the parts of the Anthropic Messages API object
+// nested in "assistant"/"user" envelope events that we care about.
+type claudeMessage struct {
+	Role    string               `json:"role"`
+	Content []claudeContentBlock `json:"content"`
+}
+
+type claudeContentBlock struct {
+	Type  string          `json:"type"` // "text" | "tool_use" | "tool_result"
+	Text  string          `json:"text,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+type claudeUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// streamClaudeEvents runs cmd, parsing its stdout as newline-delimited
+// stream-json events and dispatching each to handleClaudeEvent as it
+// arrives, rather than waiting for the process to exit. Stderr is streamed
+// line-by-line as warnings. Both scanners run in their own goroutine with a
+// bounded buffer.
+func (r *WorkerRunner) streamClaudeEvents(cmd *exec.Cmd, eventCh chan<- ExecutorEvent) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	toolCalls := 0
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), maxClaudeLineSize)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				continue
+			}
+
+			var ev claudeStreamEvent
+			if err := json.Unmarshal([]byte(line), &ev); err != nil {
+				r.logger.Debug(line, "event", "claude.stdout")
+				continue
+			}
+			r.handleClaudeEvent(ev, &toolCalls, eventCh)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stderr)
+		scanner.Buffer(make([]byte, 64*1024), maxClaudeLineSize)
+		for scanner.Scan() {
+			r.logger.Warn(scanner.Text(), "event", "claude.stderr")
+		}
+	}()
+	wg.Wait()
+
+	return cmd.Wait()
+}
+
+func (r *WorkerRunner) handleClaudeEvent(ev claudeStreamEvent, toolCalls *int, eventCh chan<- ExecutorEvent) {
+	switch ev.Type {
+	case "system":
+		r.logger.Debug("system event", "event", "claude.system", "subtype", ev.Subtype)
+		if ev.Subtype == "init" {
+			eventCh <- ExecutorEvent{Percent: 5, Message: "Claude started responding..."}
+		}
+
+	case "assistant":
+		if ev.Message == nil {
+			return
+		}
+		for _, block := range ev.Message.Content {
+			switch block.Type {
+			case "text":
+				r.logger.Debug(block.Text, "event", "claude.text")
+			case "tool_use":
+				*toolCalls++
+				summary := summarizeToolUse(block.Name, block.Input)
+				r.logger.Info(summary, "event", "claude.tool_use", "toolCalls", *toolCalls)
+				eventCh <- ExecutorEvent{Percent: progressForToolCalls(*toolCalls), Message: summary}
+			}
+		}
+
+	case "user":
+		r.logger.Debug("tool result received", "event", "claude.user")
+
+	case "result":
+		r.logger.Debug(ev.Result, "event", "claude.result", "subtype", ev.Subtype)
+		if ev.Usage != nil {
+			r.reportCost(ev.Usage.InputTokens, ev.Usage.OutputTokens)
+		}
+
+	default:
+		r.logger.Debug("unrecognized event", "event", "claude.unknown", "type", ev.Type)
+	}
+}
+
+// summarizeToolUse renders a short human-readable summary of a tool call for
+// the progress message field, e.g. "ran bash: go test ./...".
+func summarizeToolUse(name string, input json.RawMessage) string {
+	if name == "bash" || name == "Bash" {
+		var args struct {
+			Command string `json:"command"`
+		}
+		if err := json.Unmarshal(input, &args); err == nil && args.Command != "" {
+			return fmt.Sprintf("ran bash: %s", args.Command)
+		}
+	}
+	return fmt.Sprintf("used tool: %s", name)
+}
+
+// progressForToolCalls maps a running tool-call count to a coarse progress
+// percentage, capped below 100 so the final reportComplete call always
+// represents the actual end of the task.
+func progressForToolCalls(toolCalls int) int {
+	percent := 10 + toolCalls*10
+	if percent > 90 {
+		percent = 90
+	}
+	return percent
+}
+
+// reportCost reports token usage for the current run to the server's cost
+// endpoint.
+func (r *WorkerRunner) reportCost(inputTokens, outputTokens int) {
+	payload := map[string]interface{}{
+		"inputTokens":  inputTokens,
+		"outputTokens": outputTokens,
+	}
+
+	body, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST",
+		fmt.Sprintf("%s/api/workers/%s/cost", r.config.ServerURL, r.workerID),
+		bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.config.APIKey)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		r.logger.Error("failed to report cost", "error", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	r.logger.Debug("reported cost", "inputTokens", inputTokens, "outputTokens", outputTokens)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProgressForToolCalls(t *testing.T) {
+	tests := []struct {
+		toolCalls int
+		want      int
+	}{
+		{0, 10},
+		{1, 20},
+		{8, 90},
+		{9, 90},
+		{100, 90},
+	}
+
+	for _, tt := range tests {
+		if got := progressForToolCalls(tt.toolCalls); got != tt.want {
+			t.Errorf("progressForToolCalls(%d) = %d, want %d", tt.toolCalls, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeToolUse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input json.RawMessage
+		want  string
+	}{
+		{"bash", json.RawMessage(`{"command":"go test ./..."}`), "ran bash: go test ./..."},
+		{"Bash", json.RawMessage(`{"command":"ls"}`), "ran bash: ls"},
+		{"bash", json.RawMessage(`{}`), "used tool: bash"},
+		{"Read", json.RawMessage(`{"file_path":"main.go"}`), "used tool: Read"},
+	}
+
+	for _, tt := range tests {
+		if got := summarizeToolUse(tt.name, tt.input); got != tt.want {
+			t.Errorf("summarizeToolUse(%q, %s) = %q, want %q", tt.name, tt.input, got, tt.want)
+		}
+	}
+}
@@ -2,12 +2,17 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"sync"
 	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"buildd-ai/buildd/packages/agent/capabilities"
 )
 
 type ClientConfig struct {
@@ -15,12 +20,35 @@ type ClientConfig struct {
 	APIKey    string
 	Workspace string
 	MaxTasks  int
+
+	// RepoRoot is the git repository workers check out isolated worktrees
+	// from. Required unless Sandbox is SandboxNone and callers are fine with
+	// concurrent workers sharing a working tree.
+	RepoRoot string
+
+	Sandbox       SandboxMode
+	SandboxImage  string
+	SandboxCPUs   string
+	SandboxMemory string
+
+	// Executors lists enabled backends in priority order, e.g.
+	// []string{"claude-oauth", "claude-api"}.
+	Executors []string
 }
 
 type Client struct {
-	config  *ClientConfig
-	http    *http.Client
-	runners map[string]*WorkerRunner
+	config *ClientConfig
+	http   *http.Client
+	logger hclog.Logger
+
+	runnersMu sync.Mutex
+	runners   map[string]*WorkerRunner
+
+	drainMu  sync.Mutex
+	draining bool
+
+	capsMu sync.Mutex
+	caps   capabilities.Set
 }
 
 type Task struct {
@@ -46,12 +74,13 @@ type ClaimTasksResponse struct {
 	} `json:"workers"`
 }
 
-func NewClient(config *ClientConfig) *Client {
+func NewClient(config *ClientConfig, logger hclog.Logger) *Client {
 	return &Client{
 		config: config,
 		http: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		logger:  logger,
 		runners: make(map[string]*WorkerRunner),
 	}
 }
@@ -78,28 +107,164 @@ func (c *Client) Connect() error {
 
 func (c *Client) Close() {
 	// Stop all runners
-	for _, runner := range c.runners {
+	for _, runner := range c.runnerSnapshot() {
 		runner.Stop()
 	}
 }
 
-func (c *Client) Run() {
+// Drain performs a two-phase graceful shutdown: it stops claiming new
+// tasks, then for each active runner checkpoints its progress, waits up to
+// timeout for it to finish naturally, and escalates to SIGINT and finally
+// SIGKILL if it doesn't. Runners whose task did not reach reportComplete
+// have it requeued so another worker can pick it up. Drain blocks until every
+// runner has stopped.
+func (c *Client) Drain(timeout time.Duration) {
+	c.setDraining(true)
+
+	var wg sync.WaitGroup
+	for _, runner := range c.runnerSnapshot() {
+		if !runner.IsRunning() {
+			continue
+		}
+		wg.Add(1)
+		go func(r *WorkerRunner) {
+			defer wg.Done()
+			c.drainRunner(r, timeout)
+		}(runner)
+	}
+	wg.Wait()
+}
+
+func (c *Client) drainRunner(r *WorkerRunner, timeout time.Duration) {
+	if err := r.Checkpoint("paused by drain"); err != nil {
+		c.logger.Error("failed to checkpoint runner", "workerID", r.workerID, "error", err)
+	}
+
+	select {
+	case <-r.Done():
+		c.requeueIfUnfinished(r)
+		return
+	case <-time.After(timeout):
+	}
+
+	c.logger.Warn("drain timeout exceeded, interrupting runner", "workerID", r.workerID)
+	r.Interrupt()
+
+	select {
+	case <-r.Done():
+		c.requeueIfUnfinished(r)
+		return
+	case <-time.After(5 * time.Second):
+	}
+
+	c.logger.Warn("runner still running after SIGINT, killing", "workerID", r.workerID)
+	r.Stop()
+	c.requeueIfUnfinished(r)
+}
+
+// requeueIfUnfinished requeues r's task unless it already reached
+// reportComplete, whichever drain stage (natural exit, SIGINT, or SIGKILL)
+// stopped the runner.
+func (c *Client) requeueIfUnfinished(r *WorkerRunner) {
+	if r.Completed() {
+		return
+	}
+	c.requeueTask(r.task.ID)
+}
+
+// requeueTask tells the server a task didn't finish so another worker can
+// resume it.
+func (c *Client) requeueTask(taskID string) {
+	if err := c.patchJSON(fmt.Sprintf("/api/tasks/%s", taskID), map[string]interface{}{
+		"status": "queued",
+	}); err != nil {
+		c.logger.Error("failed to requeue task", "taskID", taskID, "error", err)
+	}
+}
+
+// Run drives task acquisition for the lifetime of ctx. It prefers the
+// server-push streaming endpoint, falling back to polling claimAndStartTasks
+// on a timer only if the server doesn't support streaming.
+func (c *Client) Run(ctx context.Context) {
+	err := c.streamTasks(ctx)
+	if err == errStreamUnsupported {
+		c.logger.Info("server does not support streaming, falling back to polling")
+		c.pollTasks(ctx)
+	}
+}
+
+func (c *Client) pollTasks(ctx context.Context) {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 
 	// Initial claim
 	c.claimAndStartTasks()
 
-	// Periodic polling
-	for range ticker.C {
-		c.claimAndStartTasks()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.claimAndStartTasks()
+		}
+	}
+}
+
+func (c *Client) isDraining() bool {
+	c.drainMu.Lock()
+	defer c.drainMu.Unlock()
+	return c.draining
+}
+
+func (c *Client) setDraining(draining bool) {
+	c.drainMu.Lock()
+	c.draining = draining
+	c.drainMu.Unlock()
+}
+
+// SetCapabilities replaces the capability set advertised in future claim
+// requests. Called once at startup and again on every SIGHUP re-probe.
+func (c *Client) SetCapabilities(caps capabilities.Set) {
+	c.capsMu.Lock()
+	c.caps = caps
+	c.capsMu.Unlock()
+}
+
+// Capabilities returns the most recently probed capability set.
+func (c *Client) Capabilities() capabilities.Set {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	return c.caps
+}
+
+// runnerSnapshot returns a point-in-time copy of the active runners, safe to
+// range over without holding runnersMu.
+func (c *Client) runnerSnapshot() []*WorkerRunner {
+	c.runnersMu.Lock()
+	defer c.runnersMu.Unlock()
+	snapshot := make([]*WorkerRunner, 0, len(c.runners))
+	for _, runner := range c.runners {
+		snapshot = append(snapshot, runner)
 	}
+	return snapshot
+}
+
+// getRunner looks up the runner for workerID, if any.
+func (c *Client) getRunner(workerID string) (*WorkerRunner, bool) {
+	c.runnersMu.Lock()
+	defer c.runnersMu.Unlock()
+	runner, ok := c.runners[workerID]
+	return runner, ok
 }
 
 func (c *Client) claimAndStartTasks() {
+	if c.isDraining() {
+		return
+	}
+
 	// Check how many slots we have available
 	activeCount := 0
-	for _, runner := range c.runners {
+	for _, runner := range c.runnerSnapshot() {
 		if runner.IsRunning() {
 			activeCount++
 		}
@@ -112,29 +277,102 @@ func (c *Client) claimAndStartTasks() {
 
 	// Claim tasks
 	req := ClaimTasksRequest{
-		WorkspaceID: c.config.Workspace,
-		MaxTasks:    availableSlots,
+		WorkspaceID:  c.config.Workspace,
+		MaxTasks:     availableSlots,
+		Capabilities: c.Capabilities(),
 	}
 
 	resp, err := c.claimTasks(req)
 	if err != nil {
-		log.Printf("Failed to claim tasks: %v", err)
+		c.logger.Error("failed to claim tasks", "error", err)
 		return
 	}
 
 	// Start runners for claimed tasks
 	for _, worker := range resp.Workers {
-		log.Printf("Claimed task %s: %s", worker.TaskID, worker.Task.Title)
+		c.logger.Info("claimed task", "taskID", worker.TaskID, "title", worker.Task.Title)
+		c.startRunner(worker.ID, worker.TaskID, worker.Branch, worker.Task)
+	}
+}
+
+// startRunner creates and launches a WorkerRunner for a newly assigned task,
+// whether it arrived via polling or a task.assigned stream event.
+func (c *Client) startRunner(workerID, taskID, branch string, task Task) {
+	c.runnersMu.Lock()
+	if _, exists := c.runners[workerID]; exists {
+		c.runnersMu.Unlock()
+		return
+	}
 
-		runner := NewWorkerRunner(c.config.ServerURL, c.config.APIKey, worker.ID, worker.Task)
-		c.runners[worker.ID] = runner
+	logger := c.logger.Named("worker").With("workerID", workerID, "taskID", taskID, "workspaceID", task.WorkspaceID)
+	runner := NewWorkerRunner(c.config, workerID, branch, task, logger)
+	c.runners[workerID] = runner
+	c.runnersMu.Unlock()
 
-		go func(r *WorkerRunner) {
-			if err := r.Start(); err != nil {
-				log.Printf("Worker %s failed: %v", r.workerID, err)
-			}
-		}(runner)
+	go func(r *WorkerRunner) {
+		if err := r.Start(); err != nil {
+			logger.Error("worker failed", "error", err)
+		}
+		c.runnersMu.Lock()
+		delete(c.runners, workerID)
+		c.runnersMu.Unlock()
+	}(runner)
+}
+
+// postJSON sends a JSON-encoded POST request to path on the buildd server,
+// discarding a successful response body.
+func (c *Client) postJSON(path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.config.ServerURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
 	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// patchJSON sends a JSON-encoded PATCH request to path on the buildd server,
+// discarding a successful response body.
+func (c *Client) patchJSON(path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PATCH", c.config.ServerURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
 }
 
 func (c *Client) claimTasks(req ClaimTasksRequest) (*ClaimTasksResponse, error) {
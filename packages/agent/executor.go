@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"sync"
+)
+
+// ExecutorCostModel categorizes how an executor's usage should be billed,
+// so reportComplete can include the right telemetry.
+type ExecutorCostModel string
+
+const (
+	CostModelSeat  ExecutorCostModel = "seat"
+	CostModelToken ExecutorCostModel = "token"
+	CostModelFree  ExecutorCostModel = "free"
+)
+
+// ExecutorResult is the outcome of a completed Executor.Execute call.
+type ExecutorResult struct {
+	Summary      string
+	CostModel    ExecutorCostModel
+	InputTokens  int
+	OutputTokens int
+}
+
+// ExecutorEvent is progress emitted by an Executor while it runs. The
+// WorkerRunner turns these into reportProgress calls; executors never talk
+// to the buildd server directly.
+type ExecutorEvent struct {
+	Percent int
+	Message string
+}
+
+// Executor runs a task's prompt against a specific coding agent backend.
+// Concrete executors register themselves via RegisterExecutor so new
+// backends can be added without modifying WorkerRunner.
+type Executor interface {
+	// Name identifies the executor, e.g. "claude-oauth". Used in
+	// capabilities and the --executors flag.
+	Name() string
+
+	// Available reports whether this executor's prerequisites (binary on
+	// PATH, required env vars, etc.) are met on this host.
+	Available() bool
+
+	// Execute runs prompt to completion, emitting progress on eventCh as it
+	// goes. The caller closes eventCh; Execute must not close it.
+	Execute(ctx context.Context, r *WorkerRunner, prompt string, eventCh chan<- ExecutorEvent) (ExecutorResult, error)
+}
+
+// executorFactories holds registered executor constructors, keyed by
+// Name(). Call RegisterExecutor from an init func to add a backend.
+var executorFactories = map[string]func() Executor{}
+
+func RegisterExecutor(name string, factory func() Executor) {
+	executorFactories[name] = factory
+}
+
+// selectExecutor returns the first executor from preferred (in priority
+// order) that's registered and reports itself Available on this host.
+func selectExecutor(preferred []string) (Executor, error) {
+	for _, name := range preferred {
+		factory, ok := executorFactories[name]
+		if !ok {
+			continue
+		}
+		executor := factory()
+		if executor.Available() {
+			return executor, nil
+		}
+	}
+	return nil, fmt.Errorf("no available executor among %v", preferred)
+}
+
+// commandExists reports whether name can be found on PATH.
+func commandExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// runAndLogLines runs cmd to completion, streaming its stdout/stderr line by
+// line into r's logger under the given event label rather than buffering
+// the whole run. Executors without their own structured event format (most
+// non-Claude CLIs) use this for output handling.
+func runAndLogLines(r *WorkerRunner, cmd *exec.Cmd, eventLabel string) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			r.logger.Info(scanner.Text(), "event", eventLabel+".stdout")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			r.logger.Warn(scanner.Text(), "event", eventLabel+".stderr")
+		}
+	}()
+	wg.Wait()
+
+	return cmd.Wait()
+}
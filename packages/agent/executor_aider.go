@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+func init() {
+	RegisterExecutor("aider", func() Executor { return &AiderExecutor{} })
+}
+
+// AiderExecutor runs the open-source Aider CLI against the Anthropic API.
+// Like ClaudeAPIExecutor, usage is pay-per-token.
+type AiderExecutor struct{}
+
+func (e *AiderExecutor) Name() string { return "aider" }
+
+func (e *AiderExecutor) Available() bool {
+	return commandExists("aider") && os.Getenv("ANTHROPIC_API_KEY") != ""
+}
+
+func (e *AiderExecutor) Execute(ctx context.Context, r *WorkerRunner, prompt string, eventCh chan<- ExecutorEvent) (ExecutorResult, error) {
+	r.logger.Info("executing via aider", "mode", "pay-per-token")
+
+	aiderArgs := []string{"--yes-always", "--message", prompt}
+
+	var cmd *exec.Cmd
+	if r.config.Sandbox == SandboxDocker {
+		apiKeyEnv := "ANTHROPIC_API_KEY=" + os.Getenv("ANTHROPIC_API_KEY")
+		cmd = exec.CommandContext(ctx, "docker", r.workspace.dockerArgs(r.config, []string{apiKeyEnv}, "aider", aiderArgs...)...)
+	} else {
+		cmd = exec.CommandContext(ctx, "aider", aiderArgs...)
+		if r.workspace != nil {
+			cmd.Dir = r.workspace.Dir
+		}
+		cmd.Env = os.Environ()
+	}
+
+	r.mu.Lock()
+	r.cmd = cmd
+	r.mu.Unlock()
+
+	eventCh <- ExecutorEvent{Percent: 0, Message: "Starting aider..."}
+	if err := runAndLogLines(r, cmd, "aider"); err != nil {
+		return ExecutorResult{}, fmt.Errorf("aider execution failed: %w", err)
+	}
+
+	return ExecutorResult{
+		Summary:   "Task completed successfully (aider)",
+		CostModel: CostModelToken,
+	}, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+func init() {
+	RegisterExecutor("claude-oauth", func() Executor { return &ClaudeOAuthExecutor{} })
+	RegisterExecutor("claude-api", func() Executor { return &ClaudeAPIExecutor{} })
+}
+
+// ClaudeOAuthExecutor runs the claude CLI against a user's Claude Pro/Team
+// seat via CLAUDE_CODE_OAUTH_TOKEN. Billing is per seat, not per token; the
+// token usage on the CLI's final result event is still sent via reportCost.
+type ClaudeOAuthExecutor struct{}
+
+func (e *ClaudeOAuthExecutor) Name() string { return "claude-oauth" }
+
+func (e *ClaudeOAuthExecutor) Available() bool {
+	return os.Getenv("CLAUDE_CODE_OAUTH_TOKEN") != "" && commandExists("claude")
+}
+
+func (e *ClaudeOAuthExecutor) Execute(ctx context.Context, r *WorkerRunner, prompt string, eventCh chan<- ExecutorEvent) (ExecutorResult, error) {
+	r.logger.Info("executing via OAuth", "mode", "seat-based")
+
+	// Save prompt to a file claude can read. If we have a workspace, put it
+	// there so a docker sandbox (which only has the workspace bind-mounted)
+	// can see it too.
+	promptDir := os.TempDir()
+	if r.workspace != nil {
+		promptDir = r.workspace.Dir
+	}
+	tmpFile := filepath.Join(promptDir, fmt.Sprintf("buildd-prompt-%s.txt", r.workerID))
+	if err := os.WriteFile(tmpFile, []byte(prompt), 0644); err != nil {
+		return ExecutorResult{}, fmt.Errorf("failed to write prompt: %w", err)
+	}
+	defer os.Remove(tmpFile)
+
+	eventCh <- ExecutorEvent{Percent: 0, Message: "Starting Claude (OAuth)..."}
+
+	// Execute claude CLI in stream-json mode so progress, tool calls and
+	// usage can be parsed as they happen instead of after the process exits.
+	oauthEnv := "CLAUDE_CODE_OAUTH_TOKEN=" + os.Getenv("CLAUDE_CODE_OAUTH_TOKEN")
+	claudeArgs := []string{"--dangerously-skip-permissions", "--output-format", "stream-json", "-f", tmpFile}
+
+	var cmd *exec.Cmd
+	if r.config.Sandbox == SandboxDocker {
+		cmd = exec.CommandContext(ctx, "docker", r.workspace.dockerArgs(r.config, []string{oauthEnv}, "claude", claudeArgs...)...)
+	} else {
+		cmd = exec.CommandContext(ctx, "claude", claudeArgs...)
+		cmd.Env = append(os.Environ(), oauthEnv)
+		if r.workspace != nil {
+			cmd.Dir = r.workspace.Dir
+		}
+	}
+
+	r.mu.Lock()
+	r.cmd = cmd
+	r.mu.Unlock()
+
+	if err := r.streamClaudeEvents(cmd, eventCh); err != nil {
+		return ExecutorResult{}, err
+	}
+
+	return ExecutorResult{
+		Summary:   "Task completed successfully (OAuth)",
+		CostModel: CostModelSeat,
+	}, nil
+}
+
+// ClaudeAPIExecutor runs Claude via the pay-per-token Anthropic API. Execute
+// simulates the run with fixed progress steps; it reports no cost and its
+// result carries zero token counts.
+type ClaudeAPIExecutor struct{}
+
+func (e *ClaudeAPIExecutor) Name() string { return "claude-api" }
+
+func (e *ClaudeAPIExecutor) Available() bool {
+	return os.Getenv("ANTHROPIC_API_KEY") != ""
+}
+
+func (e *ClaudeAPIExecutor) Execute(ctx context.Context, r *WorkerRunner, prompt string, eventCh chan<- ExecutorEvent) (ExecutorResult, error) {
+	r.logger.Info("executing via API", "mode", "pay-per-token")
+
+	// For now, we'll simulate work
+	// In production, this would use the Claude Agent SDK
+	steps := []struct {
+		percent int
+		message string
+	}{
+		{0, "Starting task (API)..."},
+		{30, "Analyzing requirements..."},
+		{60, "Implementing solution..."},
+		{90, "Finalizing..."},
+	}
+	for _, step := range steps {
+		eventCh <- ExecutorEvent{Percent: step.percent, Message: step.message}
+		select {
+		case <-ctx.Done():
+			return ExecutorResult{}, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	return ExecutorResult{
+		Summary:   "Task completed successfully (API)",
+		CostModel: CostModelToken,
+	}, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+func init() {
+	RegisterExecutor("codex", func() Executor { return &CodexExecutor{} })
+}
+
+// CodexExecutor runs OpenAI's Codex CLI. Usage is pay-per-token, billed
+// against whatever account OPENAI_API_KEY belongs to.
+type CodexExecutor struct{}
+
+func (e *CodexExecutor) Name() string { return "codex" }
+
+func (e *CodexExecutor) Available() bool {
+	return commandExists("codex") && os.Getenv("OPENAI_API_KEY") != ""
+}
+
+func (e *CodexExecutor) Execute(ctx context.Context, r *WorkerRunner, prompt string, eventCh chan<- ExecutorEvent) (ExecutorResult, error) {
+	r.logger.Info("executing via codex", "mode", "pay-per-token")
+
+	codexArgs := []string{"exec", "--full-auto", prompt}
+
+	var cmd *exec.Cmd
+	if r.config.Sandbox == SandboxDocker {
+		apiKeyEnv := "OPENAI_API_KEY=" + os.Getenv("OPENAI_API_KEY")
+		cmd = exec.CommandContext(ctx, "docker", r.workspace.dockerArgs(r.config, []string{apiKeyEnv}, "codex", codexArgs...)...)
+	} else {
+		cmd = exec.CommandContext(ctx, "codex", codexArgs...)
+		if r.workspace != nil {
+			cmd.Dir = r.workspace.Dir
+		}
+		cmd.Env = os.Environ()
+	}
+
+	r.mu.Lock()
+	r.cmd = cmd
+	r.mu.Unlock()
+
+	eventCh <- ExecutorEvent{Percent: 0, Message: "Starting codex..."}
+	if err := runAndLogLines(r, cmd, "codex"); err != nil {
+		return ExecutorResult{}, fmt.Errorf("codex execution failed: %w", err)
+	}
+
+	return ExecutorResult{
+		Summary:   "Task completed successfully (codex)",
+		CostModel: CostModelToken,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+)
+
+func init() {
+	RegisterExecutor("ollama", func() Executor { return &OllamaExecutor{} })
+}
+
+// OllamaExecutor runs a local model via the ollama CLI. There's no per-token
+// billing since the model runs on the worker's own hardware.
+type OllamaExecutor struct{}
+
+func (e *OllamaExecutor) Name() string { return "ollama" }
+
+func (e *OllamaExecutor) Available() bool {
+	if !commandExists("ollama") {
+		return false
+	}
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get("http://localhost:11434/api/version")
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+func (e *OllamaExecutor) Execute(ctx context.Context, r *WorkerRunner, prompt string, eventCh chan<- ExecutorEvent) (ExecutorResult, error) {
+	if r.config.Sandbox == SandboxDocker {
+		return ExecutorResult{}, fmt.Errorf("ollama executor does not support --sandbox=docker: it talks to the ollama daemon on localhost, which a sandboxed container can't reach")
+	}
+
+	model := os.Getenv("BUILDD_OLLAMA_MODEL")
+	if model == "" {
+		model = "llama3"
+	}
+	r.logger.Info("executing via ollama", "mode", "free", "model", model)
+
+	cmd := exec.CommandContext(ctx, "ollama", "run", model, prompt)
+	if r.workspace != nil {
+		cmd.Dir = r.workspace.Dir
+	}
+
+	r.mu.Lock()
+	r.cmd = cmd
+	r.mu.Unlock()
+
+	eventCh <- ExecutorEvent{Percent: 0, Message: fmt.Sprintf("Starting ollama (%s)...", model)}
+	if err := runAndLogLines(r, cmd, "ollama"); err != nil {
+		return ExecutorResult{}, fmt.Errorf("ollama execution failed: %w", err)
+	}
+
+	return ExecutorResult{
+		Summary:   "Task completed successfully (ollama)",
+		CostModel: CostModelFree,
+	}, nil
+}
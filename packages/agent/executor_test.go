@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeExecutor struct {
+	name      string
+	available bool
+}
+
+func (f *fakeExecutor) Name() string    { return f.name }
+func (f *fakeExecutor) Available() bool { return f.available }
+func (f *fakeExecutor) Execute(_ context.Context, _ *WorkerRunner, _ string, _ chan<- ExecutorEvent) (ExecutorResult, error) {
+	return ExecutorResult{}, nil
+}
+
+func withExecutors(t *testing.T, factories map[string]func() Executor) {
+	t.Helper()
+	orig := executorFactories
+	executorFactories = factories
+	t.Cleanup(func() { executorFactories = orig })
+}
+
+func TestSelectExecutorPrefersEarlierAvailable(t *testing.T) {
+	withExecutors(t, map[string]func() Executor{
+		"claude-oauth": func() Executor { return &fakeExecutor{name: "claude-oauth", available: false} },
+		"claude-api":   func() Executor { return &fakeExecutor{name: "claude-api", available: true} },
+		"aider":        func() Executor { return &fakeExecutor{name: "aider", available: true} },
+	})
+
+	executor, err := selectExecutor([]string{"claude-oauth", "claude-api", "aider"})
+	if err != nil {
+		t.Fatalf("selectExecutor returned error: %v", err)
+	}
+	if got := executor.Name(); got != "claude-api" {
+		t.Errorf("selectExecutor() = %q, want %q", got, "claude-api")
+	}
+}
+
+func TestSelectExecutorSkipsUnregistered(t *testing.T) {
+	withExecutors(t, map[string]func() Executor{
+		"aider": func() Executor { return &fakeExecutor{name: "aider", available: true} },
+	})
+
+	executor, err := selectExecutor([]string{"claude-oauth", "aider"})
+	if err != nil {
+		t.Fatalf("selectExecutor returned error: %v", err)
+	}
+	if got := executor.Name(); got != "aider" {
+		t.Errorf("selectExecutor() = %q, want %q", got, "aider")
+	}
+}
+
+func TestSelectExecutorNoneAvailable(t *testing.T) {
+	withExecutors(t, map[string]func() Executor{
+		"claude-oauth": func() Executor { return &fakeExecutor{name: "claude-oauth", available: false} },
+	})
+
+	if _, err := selectExecutor([]string{"claude-oauth"}); err == nil {
+		t.Error("selectExecutor() expected error when no executor is available, got nil")
+	}
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"buildd-ai/buildd/packages/agent/capabilities"
 )
 
 var (
@@ -14,41 +22,129 @@ var (
 	apiKey    = flag.String("api-key", getEnv("BUILDD_API_KEY", ""), "buildd API key")
 	workspace = flag.String("workspace", "", "workspace ID to claim tasks from")
 	maxTasks  = flag.Int("max-tasks", 3, "maximum concurrent tasks")
+	logLevel  = flag.String("log-level", getEnv("BUILDD_LOG_LEVEL", "info"), "log level (trace|debug|info|warn|error)")
+	logFormat = flag.String("log-format", "text", "log format (text|json)")
+
+	repoRoot      = flag.String("repo-root", getEnv("BUILDD_REPO_ROOT", ""), "path to the git repo to check out isolated per-task worktrees from")
+	sandbox       = flag.String("sandbox", "none", "process isolation mode for running claude (none|docker)")
+	sandboxImage  = flag.String("sandbox-image", "buildd/claude-runner:latest", "docker image to run claude in when --sandbox=docker")
+	sandboxCPUs   = flag.String("sandbox-cpus", "", "docker --cpus limit when --sandbox=docker")
+	sandboxMemory = flag.String("sandbox-memory", "", "docker --memory limit when --sandbox=docker")
+
+	executors = flag.String("executors", "claude-oauth,claude-api", "comma-separated executor backends to try, in priority order (claude-oauth|claude-api|aider|codex|ollama)")
+
+	drainTimeout = flag.Duration("drain-timeout", 2*time.Minute, "how long to let in-flight tasks finish naturally on shutdown before escalating to SIGINT/SIGKILL")
+
+	debugAddr = flag.String("debug-addr", getEnv("BUILDD_DEBUG_ADDR", "127.0.0.1:6061"), "local address to serve debug endpoints (e.g. /capabilities) on")
+	labels    = make(labelFlag)
 )
 
+func init() {
+	flag.Var(labels, "label", "custom capability label as key=value (repeatable)")
+}
+
+// labelFlag collects repeated --label key=value flags into a map.
+type labelFlag map[string]string
+
+func (l labelFlag) String() string {
+	parts := make([]string, 0, len(l))
+	for k, v := range l {
+		parts = append(parts, k+"="+v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l labelFlag) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("invalid --label %q, expected key=value", value)
+	}
+	l[k] = v
+	return nil
+}
+
 func main() {
 	flag.Parse()
 
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:       "buildd-agent",
+		Level:      hclog.LevelFromString(*logLevel),
+		JSONFormat: *logFormat == "json",
+	})
+
 	if *apiKey == "" {
-		log.Fatal("BUILDD_API_KEY is required (set via env or --api-key)")
+		logger.Error("BUILDD_API_KEY is required (set via env or --api-key)")
+		os.Exit(1)
 	}
 
 	config := &ClientConfig{
-		ServerURL: *serverURL,
-		APIKey:    *apiKey,
-		Workspace: *workspace,
-		MaxTasks:  *maxTasks,
+		ServerURL:     *serverURL,
+		APIKey:        *apiKey,
+		Workspace:     *workspace,
+		MaxTasks:      *maxTasks,
+		RepoRoot:      *repoRoot,
+		Sandbox:       SandboxMode(*sandbox),
+		SandboxImage:  *sandboxImage,
+		SandboxCPUs:   *sandboxCPUs,
+		SandboxMemory: *sandboxMemory,
+		Executors:     strings.Split(*executors, ","),
 	}
 
-	client := NewClient(config)
+	client := NewClient(config, logger)
+	client.SetCapabilities(computeCapabilities(config, labels))
 
 	// Connect to server
 	if err := client.Connect(); err != nil {
-		log.Fatalf("Failed to connect: %v", err)
+		logger.Error("failed to connect", "error", err)
+		os.Exit(1)
 	}
 	defer client.Close()
 
-	log.Printf("Connected to buildd server at %s", *serverURL)
+	logger.Info("connected to buildd server", "url", *serverURL)
+
+	go serveDebugEndpoints(client, logger)
 
-	// Handle shutdown gracefully
-	sigCh := make(chan os.Signal, 1)
+	// Re-probe capabilities on SIGHUP, e.g. after a toolchain or executor
+	// prerequisite changes without restarting the agent. Labels are the ones
+	// parsed from --label at startup.
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go func() {
+		for range hupCh {
+			logger.Info("SIGHUP received, re-probing capabilities")
+			client.SetCapabilities(computeCapabilities(config, labels))
+		}
+	}()
+
+	// Handle shutdown gracefully: the first signal starts a drain (stop
+	// claiming, checkpoint and let in-flight tasks finish), a second signal
+	// kills everything immediately.
+	sigCh := make(chan os.Signal, 2)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	// Start claiming and working on tasks
-	go client.Run()
+	go client.Run(ctx)
+
+	sig := <-sigCh
+	logger.Info("received signal, draining", "signal", sig, "timeout", *drainTimeout)
+
+	drained := make(chan struct{})
+	go func() {
+		client.Drain(*drainTimeout)
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		logger.Info("drain complete")
+	case sig := <-sigCh:
+		logger.Warn("second signal received, killing immediately", "signal", sig)
+		client.Close()
+	}
 
-	<-sigCh
-	log.Println("Shutting down gracefully...")
+	cancel()
 }
 
 func getEnv(key, fallback string) string {
@@ -57,3 +153,32 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// computeCapabilities probes the host and combines the result with whichever
+// configured executors are actually available, so the returned set fully
+// describes what this worker can do.
+func computeCapabilities(config *ClientConfig, labels map[string]string) capabilities.Set {
+	var available []string
+	for _, name := range config.Executors {
+		factory, ok := executorFactories[name]
+		if ok && factory().Available() {
+			available = append(available, name)
+		}
+	}
+	return capabilities.Probe(available, labels)
+}
+
+// serveDebugEndpoints runs a localhost-only HTTP server exposing debug
+// endpoints like /capabilities. It's not part of the buildd protocol.
+func serveDebugEndpoints(client *Client, logger hclog.Logger) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(client.Capabilities())
+	})
+
+	logger.Info("serving debug endpoints", "addr", *debugAddr)
+	if err := http.ListenAndServe(*debugAddr, mux); err != nil {
+		logger.Error("debug server exited", "error", err)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestLabelFlagSet(t *testing.T) {
+	l := make(labelFlag)
+
+	if err := l.Set("gpu=a100"); err != nil {
+		t.Fatalf("Set(%q) returned error: %v", "gpu=a100", err)
+	}
+	if got := l["gpu"]; got != "a100" {
+		t.Errorf("l[%q] = %q, want %q", "gpu", got, "a100")
+	}
+
+	if err := l.Set("region=us-east"); err != nil {
+		t.Fatalf("Set(%q) returned error: %v", "region=us-east", err)
+	}
+	if len(l) != 2 {
+		t.Errorf("len(l) = %d, want 2", len(l))
+	}
+
+	if err := l.Set("no-equals-sign"); err == nil {
+		t.Error("Set(\"no-equals-sign\") expected error, got nil")
+	}
+}
@@ -2,81 +2,245 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/hashicorp/go-hclog"
 )
 
-type WorkerRunner struct {
-	serverURL string
-	apiKey    string
-	workerID  string
-	task      Task
+type runnerEventKind int
+
+const (
+	runnerEventCancel runnerEventKind = iota
+	runnerEventPriority
+)
 
-	running bool
-	mu      sync.Mutex
-	cmd     *exec.Cmd
+// runnerEvent is a server-pushed event routed to a single WorkerRunner.
+type runnerEvent struct {
+	kind     runnerEventKind
+	priority int
+}
+
+type WorkerRunner struct {
+	config   *ClientConfig
+	workerID string
+	branch   string
+	task     Task
+	logger   hclog.Logger
+
+	running     bool
+	inboxClosed bool
+	completed   bool
+	progress    int
+	message     string
+	priority    int
+	mu          sync.Mutex
+	cmd         *exec.Cmd
+	cancel      context.CancelFunc
+
+	workspace *Workspace
+
+	inbox chan runnerEvent
+	done  chan struct{}
 }
 
-func NewWorkerRunner(serverURL, apiKey, workerID string, task Task) *WorkerRunner {
+func NewWorkerRunner(config *ClientConfig, workerID, branch string, task Task, logger hclog.Logger) *WorkerRunner {
 	return &WorkerRunner{
-		serverURL: serverURL,
-		apiKey:    apiKey,
-		workerID:  workerID,
-		task:      task,
+		config:   config,
+		workerID: workerID,
+		branch:   branch,
+		task:     task,
+		logger:   logger,
+		inbox:    make(chan runnerEvent, 4),
+		done:     make(chan struct{}),
 	}
 }
 
+// dispatch delivers a server-pushed event to the runner's event loop. It
+// never blocks the caller: if the inbox is full the event is dropped, since
+// a subsequent event (e.g. a later priority change) will supersede it.
+func (r *WorkerRunner) dispatch(ev runnerEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.inboxClosed {
+		return
+	}
+
+	select {
+	case r.inbox <- ev:
+	default:
+		r.logger.Warn("dropping event, inbox full")
+	}
+}
+
+// watchEvents processes server-pushed events for the lifetime of the runner.
+func (r *WorkerRunner) watchEvents() {
+	for ev := range r.inbox {
+		switch ev.kind {
+		case runnerEventCancel:
+			r.logger.Info("cancelled by server")
+			r.Stop()
+		case runnerEventPriority:
+			r.mu.Lock()
+			r.priority = ev.priority
+			r.mu.Unlock()
+			r.logger.Info("priority changed", "priority", ev.priority)
+		}
+	}
+}
+
+// Progress returns the most recently reported progress percentage and
+// message, for inclusion in client heartbeats.
+func (r *WorkerRunner) Progress() (int, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.progress, r.message
+}
+
 func (r *WorkerRunner) IsRunning() bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	return r.running
 }
 
+// Completed reports whether the runner's task reached reportComplete before
+// Start returned, as opposed to exiting early because it was cancelled,
+// interrupted, or killed during drain.
+func (r *WorkerRunner) Completed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.completed
+}
+
+// Done returns a channel that's closed once Start returns, so callers can
+// wait for the runner to finish without polling IsRunning.
+func (r *WorkerRunner) Done() <-chan struct{} {
+	return r.done
+}
+
 func (r *WorkerRunner) Start() error {
 	r.mu.Lock()
 	r.running = true
 	r.mu.Unlock()
+	defer close(r.done)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.watchEvents()
+	}()
 
 	defer func() {
+		r.mu.Lock()
+		r.inboxClosed = true
+		r.mu.Unlock()
+		close(r.inbox)
+		<-done
+
 		r.mu.Lock()
 		r.running = false
 		r.mu.Unlock()
 	}()
 
-	log.Printf("[%s] Starting work on task: %s", r.workerID, r.task.Title)
+	r.logger.Info("starting work on task", "title", r.task.Title)
+
+	if r.config.RepoRoot != "" {
+		ws := NewWorkspace(r.config.RepoRoot, r.workerID, r.branch)
+		if err := ws.Setup(); err != nil {
+			r.logger.Error("failed to set up workspace", "error", err)
+			return err
+		}
+		r.workspace = ws
+		defer func() {
+			if err := ws.Teardown(); err != nil {
+				r.logger.Error("failed to tear down workspace", "error", err)
+			}
+		}()
+	}
 
 	// Build prompt
 	prompt := r.buildPrompt()
 
-	// Execute Claude via node script
-	// This is a simplified version - in production you'd use the Claude Agent SDK
-	if err := r.executeClaude(prompt); err != nil {
-		log.Printf("[%s] Error: %v", r.workerID, err)
+	if err := r.executeTask(prompt); err != nil {
+		r.logger.Error("task failed", "error", err)
 		return err
 	}
 
-	log.Printf("[%s] Task completed", r.workerID)
+	r.logger.Info("task completed")
 	return nil
 }
 
+// Stop hard-kills the runner's executor process immediately. Use Interrupt
+// for a softer nudge during graceful drain.
 func (r *WorkerRunner) Stop() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.cancel != nil {
+		r.cancel()
+	}
 	if r.cmd != nil && r.cmd.Process != nil {
 		r.cmd.Process.Kill()
 	}
 	r.running = false
 }
 
+// Interrupt sends SIGINT to the runner's executor process, giving it a
+// chance to exit cleanly before a drain timeout escalates to Stop.
+func (r *WorkerRunner) Interrupt() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cmd != nil && r.cmd.Process != nil {
+		r.cmd.Process.Signal(syscall.SIGINT)
+	}
+}
+
+// Checkpoint reports the runner's current progress to the server along with
+// note, so the task can be resumed with useful context if this worker is
+// drained before it finishes.
+func (r *WorkerRunner) Checkpoint(note string) error {
+	percent, message := r.Progress()
+
+	payload := map[string]interface{}{
+		"progress": percent,
+		"message":  message,
+		"note":     note,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST",
+		fmt.Sprintf("%s/api/workers/%s/checkpoint", r.config.ServerURL, r.workerID),
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.config.APIKey)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	r.logger.Info("checkpointed task", "note", note, "progress", percent)
+	return nil
+}
+
 func (r *WorkerRunner) buildPrompt() string {
 	var b strings.Builder
 
@@ -95,86 +259,49 @@ func (r *WorkerRunner) buildPrompt() string {
 	return b.String()
 }
 
-func (r *WorkerRunner) executeClaude(prompt string) error {
-	// Check which auth method to use
-	if oauthToken := os.Getenv("CLAUDE_CODE_OAUTH_TOKEN"); oauthToken != "" {
-		log.Printf("[%s] Using OAuth authentication (seat-based)", r.workerID)
-		return r.executeViaOAuth(prompt)
-	}
-
-	if apiKey := os.Getenv("ANTHROPIC_API_KEY"); apiKey != "" {
-		log.Printf("[%s] Using API authentication (pay-per-token)", r.workerID)
-		return r.executeViaAPI(prompt)
-	}
-
-	return fmt.Errorf("no authentication configured - set CLAUDE_CODE_OAUTH_TOKEN or ANTHROPIC_API_KEY")
-}
-
-func (r *WorkerRunner) executeViaOAuth(prompt string) error {
-	// Use claude CLI with OAuth token
-	// This uses the user's Claude Pro/Team seat - no per-token cost
-	log.Printf("[%s] Executing via OAuth (seat-based, no cost tracking)", r.workerID)
-
-	// Save prompt to temp file
-	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("buildd-prompt-%s.txt", r.workerID))
-	if err := os.WriteFile(tmpFile, []byte(prompt), 0644); err != nil {
-		return fmt.Errorf("failed to write prompt: %w", err)
+// executeTask selects the first available executor (in the priority order
+// given by --executors) and runs prompt through it, forwarding its progress
+// events to reportProgress and its final result to reportComplete.
+func (r *WorkerRunner) executeTask(prompt string) error {
+	executor, err := selectExecutor(r.config.Executors)
+	if err != nil {
+		return err
 	}
-	defer os.Remove(tmpFile)
-
-	// Report progress
-	r.reportProgress(0, "Starting Claude (OAuth)...")
-
-	// Execute claude CLI
-	// NOTE: In production, this would stream output and parse for progress
-	cmd := exec.Command("claude", "--dangerously-skip-permissions", "-f", tmpFile)
-	cmd.Env = append(os.Environ(),
-		"CLAUDE_CODE_OAUTH_TOKEN="+os.Getenv("CLAUDE_CODE_OAUTH_TOKEN"))
+	r.logger.Info("selected executor", "executor", executor.Name())
 
+	ctx, cancel := context.WithCancel(context.Background())
 	r.mu.Lock()
-	r.cmd = cmd
+	r.cancel = cancel
 	r.mu.Unlock()
+	defer cancel()
+
+	eventCh := make(chan ExecutorEvent)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range eventCh {
+			r.reportProgress(ev.Percent, ev.Message)
+		}
+	}()
+
+	result, err := executor.Execute(ctx, r, prompt, eventCh)
+	close(eventCh)
+	<-done
 
-	output, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Printf("[%s] Claude execution failed: %v\n%s", r.workerID, err, string(output))
-		return fmt.Errorf("claude execution failed: %w", err)
+		return fmt.Errorf("%s execution failed: %w", executor.Name(), err)
 	}
 
-	log.Printf("[%s] Claude output:\n%s", r.workerID, string(output))
-
-	// Mark as complete (no cost to report for OAuth)
-	r.reportComplete("Task completed successfully (OAuth)")
-	return nil
-}
-
-func (r *WorkerRunner) executeViaAPI(prompt string) error {
-	// Use Anthropic API with API key
-	// This is pay-per-token - costs are tracked
-	log.Printf("[%s] Executing via API (pay-per-token, cost tracking enabled)", r.workerID)
-
-	// For now, we'll simulate work
-	// In production, this would use the Claude Agent SDK
-
-	r.reportProgress(0, "Starting task (API)...")
-	time.Sleep(2 * time.Second)
-
-	r.reportProgress(30, "Analyzing requirements...")
-	time.Sleep(2 * time.Second)
-
-	r.reportProgress(60, "Implementing solution...")
-	time.Sleep(2 * time.Second)
-
-	r.reportProgress(90, "Finalizing...")
-	time.Sleep(1 * time.Second)
-
-	// Mark as complete
-	r.reportComplete("Task completed successfully (API)")
-
+	r.reportComplete(result)
 	return nil
 }
 
 func (r *WorkerRunner) reportProgress(percent int, message string) {
+	r.mu.Lock()
+	r.progress = percent
+	r.message = message
+	r.mu.Unlock()
+
 	payload := map[string]interface{}{
 		"progress": percent,
 		"status":   "running",
@@ -182,44 +309,53 @@ func (r *WorkerRunner) reportProgress(percent int, message string) {
 
 	body, _ := json.Marshal(payload)
 	req, _ := http.NewRequest("PATCH",
-		fmt.Sprintf("%s/api/workers/%s", r.serverURL, r.workerID),
+		fmt.Sprintf("%s/api/workers/%s", r.config.ServerURL, r.workerID),
 		bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Authorization", "Bearer "+r.config.APIKey)
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[%s] Failed to report progress: %v", r.workerID, err)
+		r.logger.Error("failed to report progress", "error", err)
 		return
 	}
 	defer resp.Body.Close()
 
-	log.Printf("[%s] Progress: %d%% - %s", r.workerID, percent, message)
+	r.logger.Debug("reported progress", "percent", percent, "message", message)
 }
 
-func (r *WorkerRunner) reportComplete(result string) {
+func (r *WorkerRunner) reportComplete(result ExecutorResult) {
 	payload := map[string]interface{}{
-		"status": "completed",
-		"result": result,
+		"status":    "completed",
+		"result":    result.Summary,
+		"costModel": result.CostModel,
+	}
+	if result.CostModel == CostModelToken {
+		payload["inputTokens"] = result.InputTokens
+		payload["outputTokens"] = result.OutputTokens
 	}
 
 	body, _ := json.Marshal(payload)
 	req, _ := http.NewRequest("PATCH",
-		fmt.Sprintf("%s/api/workers/%s", r.serverURL, r.workerID),
+		fmt.Sprintf("%s/api/workers/%s", r.config.ServerURL, r.workerID),
 		bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Authorization", "Bearer "+r.config.APIKey)
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[%s] Failed to report completion: %v", r.workerID, err)
+		r.logger.Error("failed to report completion", "error", err)
 		return
 	}
 	defer resp.Body.Close()
 
-	log.Printf("[%s] Completed: %s", r.workerID, result)
+	r.logger.Info("task reported complete", "result", result.Summary, "costModel", result.CostModel)
+
+	r.mu.Lock()
+	r.completed = true
+	r.mu.Unlock()
 }
 
 // Helper to execute commands
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errStreamUnsupported is returned when the server doesn't implement the
+// streaming endpoint, signalling the caller to fall back to HTTP polling.
+var errStreamUnsupported = errors.New("server does not support task streaming")
+
+const (
+	minReconnectBackoff = 1 * time.Second
+	maxReconnectBackoff = 30 * time.Second
+	heartbeatInterval   = 15 * time.Second
+)
+
+// StreamEvent is a single server-sent event pushed over /api/workers/stream.
+type StreamEvent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// taskAssignedEvent is the payload of a task.assigned event.
+type taskAssignedEvent struct {
+	ID     string `json:"id"`
+	TaskID string `json:"taskId"`
+	Branch string `json:"branch"`
+	Task   Task   `json:"task"`
+}
+
+// taskCancelEvent is the payload of a task.cancel event.
+type taskCancelEvent struct {
+	ID string `json:"id"`
+}
+
+// taskPriorityChangedEvent is the payload of a task.priority_changed event.
+type taskPriorityChangedEvent struct {
+	ID       string `json:"id"`
+	Priority int    `json:"priority"`
+}
+
+// streamTasks maintains a long-lived connection to /api/workers/stream,
+// dispatching events to the appropriate runners until ctx is cancelled. It
+// reconnects with exponential backoff + jitter on transient failures, and
+// returns errStreamUnsupported immediately if the server reports the
+// endpoint doesn't exist so the caller can fall back to polling.
+func (c *Client) streamTasks(ctx context.Context) error {
+	backoff := minReconnectBackoff
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err := c.runStream(ctx, &backoff)
+		if err == errStreamUnsupported {
+			return err
+		}
+		if err != nil {
+			c.logger.Warn("stream connection lost, reconnecting", "error", err, "backoff", backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jitter(backoff)):
+		}
+
+		backoff *= 2
+		if backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
+
+// runStream opens a single SSE connection and reads events until it drops or
+// ctx is cancelled. Once the connection is established, *backoff is reset to
+// minReconnectBackoff so a later drop doesn't pay for a stale accumulated
+// backoff from before this connection succeeded.
+func (c *Client) runStream(ctx context.Context, backoff *time.Duration) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.config.ServerURL+"/api/workers/stream", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+	// c.http's Timeout also bounds reading the body, which would cut the
+	// stream off; this connection is bounded by ctx instead.
+	streamHTTP := &http.Client{Transport: c.http.Transport}
+	resp, err := streamHTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented {
+		return errStreamUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("stream endpoint returned %d", resp.StatusCode)
+	}
+
+	c.logger.Info("connected to task stream", "url", c.config.ServerURL)
+	*backoff = minReconnectBackoff
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go c.heartbeatLoop(heartbeatCtx)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+
+		var ev StreamEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			c.logger.Error("failed to decode stream event", "error", err)
+			continue
+		}
+		c.handleStreamEvent(ev)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("stream closed by server")
+}
+
+func (c *Client) handleStreamEvent(ev StreamEvent) {
+	switch ev.Type {
+	case "task.assigned":
+		var data taskAssignedEvent
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			c.logger.Error("failed to decode task.assigned event", "error", err)
+			return
+		}
+		c.startRunner(data.ID, data.TaskID, data.Branch, data.Task)
+
+	case "task.cancel":
+		var data taskCancelEvent
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			c.logger.Error("failed to decode task.cancel event", "error", err)
+			return
+		}
+		if runner, ok := c.getRunner(data.ID); ok {
+			runner.dispatch(runnerEvent{kind: runnerEventCancel})
+		}
+
+	case "task.priority_changed":
+		var data taskPriorityChangedEvent
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			c.logger.Error("failed to decode task.priority_changed event", "error", err)
+			return
+		}
+		if runner, ok := c.getRunner(data.ID); ok {
+			runner.dispatch(runnerEvent{kind: runnerEventPriority, priority: data.Priority})
+		}
+
+	case "server.drain":
+		c.logger.Info("server requested drain, pausing new task claims")
+		c.setDraining(true)
+
+	default:
+		c.logger.Warn("ignoring unknown stream event type", "type", ev.Type)
+	}
+}
+
+// heartbeatLoop periodically reports slot availability and per-runner
+// progress so the server can make scheduling decisions between pushes.
+func (c *Client) heartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.sendHeartbeat()
+		}
+	}
+}
+
+type heartbeatWorkerState struct {
+	ID       string `json:"id"`
+	Progress int    `json:"progress"`
+	Message  string `json:"message"`
+}
+
+type heartbeatPayload struct {
+	AvailableSlots int                    `json:"availableSlots"`
+	Workers        []heartbeatWorkerState `json:"workers"`
+}
+
+func (c *Client) sendHeartbeat() {
+	runners := c.runnerSnapshot()
+	activeCount := 0
+	workers := make([]heartbeatWorkerState, 0, len(runners))
+	for _, runner := range runners {
+		if !runner.IsRunning() {
+			continue
+		}
+		activeCount++
+		progress, message := runner.Progress()
+		workers = append(workers, heartbeatWorkerState{ID: runner.workerID, Progress: progress, Message: message})
+	}
+
+	payload := heartbeatPayload{
+		AvailableSlots: c.config.MaxTasks - activeCount,
+		Workers:        workers,
+	}
+
+	if err := c.postJSON("/api/workers/heartbeat", payload); err != nil {
+		c.logger.Error("failed to send heartbeat", "error", err)
+	}
+}
+
+// jitter returns d plus up to 50% random jitter, to avoid reconnect storms.
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
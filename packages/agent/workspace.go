@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// SandboxMode selects how the claude process is isolated from the host
+// while it works in a Workspace.
+type SandboxMode string
+
+const (
+	SandboxNone   SandboxMode = "none"
+	SandboxDocker SandboxMode = "docker"
+)
+
+// Workspace is an isolated git worktree checked out on a task's branch, so
+// that concurrent WorkerRunners (MaxTasks > 1) never clobber each other's
+// working tree.
+type Workspace struct {
+	repoRoot string
+	branch   string
+
+	// Dir is the worktree's path, e.g. ${TMPDIR}/buildd/${workerID}.
+	Dir string
+}
+
+// NewWorkspace returns a Workspace for workerID checked out on branch,
+// rooted under repoRoot. Setup must be called before Dir is usable.
+func NewWorkspace(repoRoot, workerID, branch string) *Workspace {
+	return &Workspace{
+		repoRoot: repoRoot,
+		branch:   branch,
+		Dir:      filepath.Join(os.TempDir(), "buildd", workerID),
+	}
+}
+
+// Setup creates the worktree via `git worktree add`, checking out branch.
+func (w *Workspace) Setup() error {
+	if err := os.MkdirAll(filepath.Dir(w.Dir), 0755); err != nil {
+		return fmt.Errorf("failed to create workspace parent dir: %w", err)
+	}
+
+	cmd := exec.Command("git", "worktree", "add", w.Dir, w.branch)
+	cmd.Dir = w.repoRoot
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git worktree add failed: %w: %s", err, string(out))
+	}
+	return nil
+}
+
+// Teardown removes the worktree, discarding any uncommitted changes left in
+// it.
+func (w *Workspace) Teardown() error {
+	cmd := exec.Command("git", "worktree", "remove", "--force", w.Dir)
+	cmd.Dir = w.repoRoot
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git worktree remove failed: %w: %s", err, string(out))
+	}
+	return nil
+}
+
+// dockerArgs builds the `docker run` argument list to execute name/args
+// inside a container with the workspace bind-mounted read-write, the repo
+// root read-only, and CPU/memory limits applied from the runner's sandbox
+// config. The whole repoRoot/.git directory (which holds the worktree's
+// gitdir under .git/worktrees and the shared objects/refs/logs it writes
+// through to) is re-mounted read-write over the read-only repoRoot mount,
+// since `git commit` inside the worktree needs to write there even though
+// the checked-out files it touches all live under Dir. The caller still runs this via exec.CommandContext("docker",
+// ...) so cancellation works the same way as the unsandboxed path.
+func (w *Workspace) dockerArgs(config *ClientConfig, env []string, name string, args ...string) []string {
+	gitDir := filepath.Join(w.repoRoot, ".git")
+	dockerArgs := []string{
+		"run", "--rm",
+		"-v", fmt.Sprintf("%s:%s:rw", w.Dir, w.Dir),
+		"-v", fmt.Sprintf("%s:%s:ro", w.repoRoot, w.repoRoot),
+		"-v", fmt.Sprintf("%s:%s:rw", gitDir, gitDir),
+		"-w", w.Dir,
+	}
+	if config.SandboxCPUs != "" {
+		dockerArgs = append(dockerArgs, "--cpus", config.SandboxCPUs)
+	}
+	if config.SandboxMemory != "" {
+		dockerArgs = append(dockerArgs, "--memory", config.SandboxMemory)
+	}
+	for _, e := range env {
+		dockerArgs = append(dockerArgs, "-e", e)
+	}
+
+	dockerArgs = append(dockerArgs, config.SandboxImage, name)
+	dockerArgs = append(dockerArgs, args...)
+
+	return dockerArgs
+}